@@ -3,16 +3,20 @@ module github.com/saiset-co/sai-service
 go 1.21
 
 require (
+	github.com/oschwald/geoip2-golang v1.9.0
 	github.com/rs/cors v1.10.1
 	github.com/urfave/cli/v2 v2.27.1
 	go.uber.org/zap v1.26.0
-	golang.org/x/net v0.20.0
+	golang.org/x/net v0.35.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
+	github.com/oschwald/maxminddb-golang v1.11.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
 )
@@ -0,0 +1,17 @@
+// Package http3 adds the experimental HTTP/3 (QUIC) listener to sai-service. It is a separate module,
+// so services without HTTP/3 don't depend on quic-go and the Go version it requires
+package http3
+
+import (
+	"net/http"
+
+	quic "github.com/quic-go/quic-go/http3"
+	"github.com/saiset-co/sai-service/service"
+)
+
+// Register enables the http3 option of the service TLS listeners
+func Register(s *service.Service) {
+	s.RegisterHttp3(func(addr string, handler http.Handler) service.Http3Server {
+		return &quic.Server{Addr: addr, Handler: handler}
+	})
+}
@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+// AdaptiveLimiter limits concurrent requests with AIMD: the limit starts at MaxLimit, grows by one per
+// window of successful requests and shrinks by Backoff on slow or failed ones. Requests started before
+// the last decrease don't shrink it again, so a burst of slow requests backs off once per round trip
+type AdaptiveLimiter struct {
+	MinLimit      int
+	MaxLimit      int
+	TargetLatency time.Duration
+	Backoff       float64
+
+	mu          sync.Mutex
+	limit       float64
+	inFlight    int
+	decreasedAt time.Time
+}
+
+func NewAdaptiveLimiter(minLimit, maxLimit int, targetLatency time.Duration) *AdaptiveLimiter {
+	if minLimit < 1 {
+		minLimit = 1
+	}
+
+	if maxLimit < minLimit {
+		maxLimit = minLimit
+	}
+
+	return &AdaptiveLimiter{
+		MinLimit:      minLimit,
+		MaxLimit:      maxLimit,
+		TargetLatency: targetLatency,
+		Backoff:       0.9,
+		limit:         float64(maxLimit),
+	}
+}
+
+// Limit returns the current concurrency limit, use it as a gauge
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.limit)
+}
+
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight
+}
+
+func (l *AdaptiveLimiter) Middleware(next service.HandlerFunc, data interface{}, metadata interface{}) (result interface{}, status int, err error) {
+	if !l.acquire() {
+		log.Println("adaptiveLimiter: concurrency limit reached")
+		return nil, http.StatusServiceUnavailable, errors.New("service overloaded")
+	}
+
+	started := time.Now()
+	// status stays 500 if next panics, so the panic counts as a failure
+	status = http.StatusInternalServerError
+	defer func() {
+		l.release(started, status >= http.StatusInternalServerError)
+	}()
+
+	return next(data, metadata)
+}
+
+func (l *AdaptiveLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		return false
+	}
+
+	l.inFlight++
+
+	return true
+}
+
+func (l *AdaptiveLimiter) release(started time.Time, failed bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	now := time.Now()
+
+	if failed || (l.TargetLatency > 0 && now.Sub(started) > l.TargetLatency) {
+		// The request ran under the limit from before the last decrease, it is already accounted for
+		if started.Before(l.decreasedAt) {
+			return
+		}
+
+		l.limit = max(float64(l.MinLimit), l.limit*l.Backoff)
+		l.decreasedAt = now
+		return
+	}
+
+	l.limit = min(float64(l.MaxLimit), l.limit+1/l.limit)
+}
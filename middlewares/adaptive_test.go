@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiterReleasesOnPanic(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 10, time.Second)
+
+	func() {
+		defer func() { _ = recover() }()
+
+		l.Middleware(func(data interface{}, metadata interface{}) (interface{}, int, error) {
+			panic("handler failure")
+		}, nil, nil)
+	}()
+
+	if inFlight := l.InFlight(); inFlight != 0 {
+		t.Fatalf("expected no requests in flight after panic, got %d", inFlight)
+	}
+
+	_, status, err := l.Middleware(func(data interface{}, metadata interface{}) (interface{}, int, error) {
+		return "ok", http.StatusOK, nil
+	}, nil, nil)
+
+	if err != nil || status != http.StatusOK {
+		t.Errorf("expected next request to pass, got %d %v", status, err)
+	}
+}
+
+func TestAdaptiveLimiterStartsAtMaxLimit(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 100, time.Second)
+
+	for i := 0; i < 100; i++ {
+		if !l.acquire() {
+			t.Fatalf("request %d rejected at cold start", i+1)
+		}
+	}
+
+	if l.acquire() {
+		t.Errorf("expected request over MaxLimit to be rejected")
+	}
+}
+
+func TestAdaptiveLimiterBacksOffOncePerRoundTrip(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 100, time.Second)
+
+	started := time.Now()
+	for i := 0; i < 50; i++ {
+		l.acquire()
+	}
+
+	// 50 concurrent failures started under the same limit shrink it once
+	for i := 0; i < 50; i++ {
+		l.release(started, true)
+	}
+
+	if limit := l.Limit(); limit != 90 {
+		t.Fatalf("expected limit 90 after one backoff, got %d", limit)
+	}
+
+	// A failure started after the decrease shrinks it again
+	l.acquire()
+	l.release(time.Now(), true)
+
+	if limit := l.Limit(); limit != 81 {
+		t.Fatalf("expected limit 81 after the second backoff, got %d", limit)
+	}
+
+	// The limit grows by one per window of successes
+	for i := 0; i < 82; i++ {
+		l.acquire()
+		l.release(time.Now(), false)
+	}
+
+	if limit := l.Limit(); limit != 82 {
+		t.Errorf("expected limit 82 after a window of successes, got %d", limit)
+	}
+}
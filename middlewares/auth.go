@@ -43,12 +43,12 @@ func CreateAuthMiddleware(authServiceURL string, microserviceName string, method
 
 		metadataMap := metadata.(map[string]interface{})
 
-		if metadataMap["token"] == nil {
+		if metadataMap[service.MetadataToken] == nil {
 			log.Println("authMiddleware: metadata token is nil")
 			return unauthorizedResponse("empty metadata token")
 		}
 
-		dataMap["token"] = metadataMap["token"]
+		dataMap["token"] = metadataMap[service.MetadataToken]
 
 		authReq := Request{
 			Method: "check",
@@ -0,0 +1,83 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+// ConcurrencyLimiter allows MaxConcurrent handler calls at once and keeps up to QueueSize
+// callers waiting for QueueTimeout. Callers over the queue are rejected with 429, callers
+// which time out in the queue with 503, both with Retry-After
+type ConcurrencyLimiter struct {
+	MaxConcurrent int
+	QueueSize     int
+	QueueTimeout  time.Duration
+	RetryAfter    time.Duration
+
+	slots    chan struct{}
+	queued   atomic.Int64
+	rejected atomic.Int64
+}
+
+type ConcurrencyStats struct {
+	InFlight int   `json:"in_flight"`
+	Queued   int64 `json:"queued"`
+	Rejected int64 `json:"rejected"`
+}
+
+func NewConcurrencyLimiter(maxConcurrent, queueSize int, queueTimeout time.Duration) *ConcurrencyLimiter {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	return &ConcurrencyLimiter{
+		MaxConcurrent: maxConcurrent,
+		QueueSize:     queueSize,
+		QueueTimeout:  queueTimeout,
+		RetryAfter:    time.Second,
+		slots:         make(chan struct{}, maxConcurrent),
+	}
+}
+
+// Stats returns saturation of the limiter
+func (l *ConcurrencyLimiter) Stats() ConcurrencyStats {
+	return ConcurrencyStats{
+		InFlight: len(l.slots),
+		Queued:   l.queued.Load(),
+		Rejected: l.rejected.Load(),
+	}
+}
+
+func (l *ConcurrencyLimiter) Middleware(next service.HandlerFunc, data interface{}, metadata interface{}) (interface{}, int, error) {
+	select {
+	case l.slots <- struct{}{}:
+	default:
+		if l.queued.Add(1) > int64(l.QueueSize) {
+			l.queued.Add(-1)
+			l.rejected.Add(1)
+			log.Println("concurrencyLimiter: queue is full")
+			return nil, http.StatusTooManyRequests, &service.RetryAfterError{Err: errors.New("too many requests"), After: l.RetryAfter}
+		}
+
+		timer := time.NewTimer(l.QueueTimeout)
+		select {
+		case l.slots <- struct{}{}:
+			timer.Stop()
+			l.queued.Add(-1)
+		case <-timer.C:
+			l.queued.Add(-1)
+			l.rejected.Add(1)
+			log.Println("concurrencyLimiter: queue timeout")
+			return nil, http.StatusServiceUnavailable, &service.RetryAfterError{Err: errors.New("service busy"), After: l.RetryAfter}
+		}
+	}
+
+	defer func() { <-l.slots }()
+
+	return next(data, metadata)
+}
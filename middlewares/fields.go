@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+// CreateFieldsMiddleware filters the handler result down to the fields requested in
+// metadata "fields" (comma separated, dot for nesting, e.g. "id,user.name").
+// Only paths from the allowed list can be requested, without "fields" the result is untouched.
+func CreateFieldsMiddleware(allowed []string) func(next service.HandlerFunc, data interface{}, metadata interface{}) (interface{}, int, error) {
+	allowedPaths := map[string]bool{}
+	for _, path := range allowed {
+		allowedPaths[path] = true
+	}
+
+	return func(next service.HandlerFunc, data interface{}, metadata interface{}) (interface{}, int, error) {
+		fields := requestedFields(metadata)
+		if len(fields) == 0 {
+			return next(data, metadata)
+		}
+
+		paths := make([][]string, 0, len(fields))
+		for _, field := range fields {
+			if !allowedPaths[field] {
+				return nil, http.StatusBadRequest, fmt.Errorf("field %s is not allowed", field)
+			}
+			paths = append(paths, strings.Split(field, "."))
+		}
+
+		result, status, err := next(data, metadata)
+		if err != nil {
+			return result, status, err
+		}
+
+		resultBytes, err := json.Marshal(result)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+
+		// UseNumber keeps large integers such as int64 IDs exact
+		decoder := json.NewDecoder(bytes.NewReader(resultBytes))
+		decoder.UseNumber()
+
+		var generic interface{}
+		if err = decoder.Decode(&generic); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+
+		return filterFields(generic, paths), status, nil
+	}
+}
+
+func requestedFields(metadata interface{}) []string {
+	metadataMap, ok := metadata.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var fields []string
+
+	switch value := metadataMap[service.MetadataFields].(type) {
+	case string:
+		fields = strings.Split(value, ",")
+	case []interface{}:
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				fields = append(fields, str)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			result = append(result, field)
+		}
+	}
+
+	return result
+}
+
+func filterFields(value interface{}, paths [][]string) interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			v[i] = filterFields(item, paths)
+		}
+		return v
+	case map[string]interface{}:
+		nested := map[string][][]string{}
+		whole := map[string]bool{}
+
+		for _, path := range paths {
+			if len(path) == 1 {
+				whole[path[0]] = true
+				continue
+			}
+			nested[path[0]] = append(nested[path[0]], path[1:])
+		}
+
+		result := map[string]interface{}{}
+		for key, item := range v {
+			if whole[key] {
+				result[key] = item
+			} else if subPaths, ok := nested[key]; ok {
+				result[key] = filterFields(item, subPaths)
+			}
+		}
+
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+func TestFieldsMiddleware(t *testing.T) {
+	middleware := CreateFieldsMiddleware([]string{"id", "user.name"})
+
+	handler := func(data interface{}, metadata interface{}) (interface{}, int, error) {
+		return map[string]interface{}{
+			"id":     int64(9007199254740993),
+			"secret": "hidden",
+			"user":   map[string]interface{}{"name": "bob", "email": "bob@example.com"},
+		}, http.StatusOK, nil
+	}
+
+	tests := []struct {
+		name     string
+		fields   string
+		status   int
+		expected string
+	}{
+		{"large integer id", "id", http.StatusOK, `{"id":9007199254740993}`},
+		{"nested field", "id,user.name", http.StatusOK, `{"id":9007199254740993,"user":{"name":"bob"}}`},
+		{"not allowed", "secret", http.StatusBadRequest, ``},
+	}
+
+	for _, test := range tests {
+		metadata := map[string]interface{}{service.MetadataFields: test.fields}
+
+		result, status, _ := middleware(handler, nil, metadata)
+		if status != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.status, status)
+			continue
+		}
+
+		if test.expected == "" {
+			continue
+		}
+
+		body, _ := json.Marshal(result)
+		if string(body) != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, body)
+		}
+	}
+}
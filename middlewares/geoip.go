@@ -0,0 +1,96 @@
+package middlewares
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/saiset-co/sai-service/service"
+)
+
+// GeoIP adds the client country (ISO code) and ASN of the connection address to metadata from MaxMind databases
+// and rejects requests from BlockedCountries with 403. Unknown and private IPs are not blocked
+type GeoIP struct {
+	BlockedCountries map[string]bool
+
+	country *geoip2.Reader
+	asn     *geoip2.Reader
+}
+
+// NewGeoIP opens GeoIP2/GeoLite2 Country (or City) and ASN databases, either path may be empty
+func NewGeoIP(countryDB, asnDB string, blockedCountries []string) (*GeoIP, error) {
+	if countryDB == "" && asnDB == "" {
+		return nil, errors.New("geoIP: no database provided")
+	}
+
+	g := &GeoIP{BlockedCountries: map[string]bool{}}
+
+	for _, country := range blockedCountries {
+		g.BlockedCountries[strings.ToUpper(country)] = true
+	}
+
+	var err error
+
+	if countryDB != "" {
+		if g.country, err = geoip2.Open(countryDB); err != nil {
+			return nil, err
+		}
+	}
+
+	if asnDB != "" {
+		if g.asn, err = geoip2.Open(asnDB); err != nil {
+			g.Close()
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+func (g *GeoIP) Close() error {
+	var errs []error
+
+	if g.country != nil {
+		errs = append(errs, g.country.Close())
+	}
+
+	if g.asn != nil {
+		errs = append(errs, g.asn.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (g *GeoIP) Middleware(next service.HandlerFunc, data interface{}, metadata interface{}) (interface{}, int, error) {
+	// Values not found below must not be taken from the client
+	if metadataMap, ok := metadata.(map[string]interface{}); ok {
+		delete(metadataMap, service.MetadataCountry)
+		delete(metadataMap, service.MetadataASN)
+	}
+
+	ipString, _ := service.GetMetadata[string](metadata, service.MetadataClientIP)
+	ip := net.ParseIP(ipString)
+	if ip == nil {
+		return next(data, metadata)
+	}
+
+	if g.country != nil {
+		if record, err := g.country.Country(ip); err == nil && record.Country.IsoCode != "" {
+			service.SetMetadata(metadata, service.MetadataCountry, record.Country.IsoCode)
+
+			if g.BlockedCountries[record.Country.IsoCode] {
+				return nil, http.StatusForbidden, errors.New("access denied")
+			}
+		}
+	}
+
+	if g.asn != nil {
+		if record, err := g.asn.ASN(ip); err == nil && record.AutonomousSystemNumber != 0 {
+			service.SetMetadata(metadata, service.MetadataASN, record.AutonomousSystemNumber)
+		}
+	}
+
+	return next(data, metadata)
+}
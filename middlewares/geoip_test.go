@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+func TestGeoIPDropsClientValues(t *testing.T) {
+	g := &GeoIP{BlockedCountries: map[string]bool{}}
+
+	metadata := map[string]interface{}{
+		service.MetadataClientIP: "203.0.113.9",
+		service.MetadataCountry:  "US",
+		service.MetadataASN:      uint(1),
+	}
+
+	_, status, err := g.Middleware(func(data interface{}, metadata interface{}) (interface{}, int, error) {
+		return nil, http.StatusOK, nil
+	}, nil, metadata)
+
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("unexpected result %d %v", status, err)
+	}
+
+	for _, key := range []string{service.MetadataCountry, service.MetadataASN} {
+		if _, ok := metadata[key]; ok {
+			t.Errorf("client value of %s was kept", key)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+// RateLimitStore keeps token buckets, implement it to share limits between instances (e.g. in Redis)
+type RateLimitStore interface {
+	// Take removes a token from the bucket of key, retryAfter is the wait for the next token when not allowed
+	Take(key string, rate float64, burst int) (allowed bool, remaining int, retryAfter time.Duration, err error)
+}
+
+// RateLimiter allows Rate requests per second with bursts of Burst per key, the rest are rejected with 429.
+// Register it globally or per handler for different limits
+type RateLimiter struct {
+	Rate    float64
+	Burst   int
+	KeyFunc func(data interface{}, metadata interface{}) string
+	Store   RateLimitStore
+
+	rejected atomic.Int64
+}
+
+func NewRateLimiter(rate float64, burst int, keyFunc func(data interface{}, metadata interface{}) string) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	if keyFunc == nil {
+		keyFunc = RateLimitByIP
+	}
+
+	return &RateLimiter{
+		Rate:    rate,
+		Burst:   burst,
+		KeyFunc: keyFunc,
+		Store:   NewMemoryRateLimitStore(),
+	}
+}
+
+// RateLimitByIP keys the limit by the connection address (see common.trusted_proxies)
+func RateLimitByIP(data interface{}, metadata interface{}) string {
+	ip, _ := service.GetMetadata[string](metadata, service.MetadataClientIP)
+	return "ip:" + ip
+}
+
+// RateLimitByToken keys the limit by metadata token, requests without a token are keyed by IP.
+// The token is sent by the client, so register the limiter after the auth middleware which
+// rejects unknown tokens, otherwise random tokens get fresh buckets
+func RateLimitByToken(data interface{}, metadata interface{}) string {
+	if token, ok := service.GetMetadata[string](metadata, service.MetadataToken); ok && token != "" {
+		return "token:" + token
+	}
+
+	return RateLimitByIP(data, metadata)
+}
+
+// Rejected returns the number of rejected requests
+func (l *RateLimiter) Rejected() int64 {
+	return l.rejected.Load()
+}
+
+func (l *RateLimiter) Middleware(next service.HandlerFunc, data interface{}, metadata interface{}) (interface{}, int, error) {
+	allowed, remaining, retryAfter, err := l.Store.Take(l.KeyFunc(data, metadata), l.Rate, l.Burst)
+	if err != nil {
+		log.Println("rateLimiter: store error: ", err)
+		return next(data, metadata)
+	}
+
+	service.SetResponseHeader(metadata, "X-RateLimit-Limit", strconv.Itoa(l.Burst))
+	service.SetResponseHeader(metadata, "X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+	if !allowed {
+		l.rejected.Add(1)
+		return nil, http.StatusTooManyRequests, &service.RetryAfterError{Err: errors.New("rate limit exceeded"), After: retryAfter}
+	}
+
+	return next(data, metadata)
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+const defaultMaxBuckets = 100000
+
+// MemoryRateLimitStore keeps buckets in process memory, limits are per instance.
+// Up to MaxBuckets keys are tracked, over it refilled buckets are dropped first, then arbitrary ones
+type MemoryRateLimitStore struct {
+	MaxBuckets int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{MaxBuckets: defaultMaxBuckets, buckets: map[string]*tokenBucket{}, swept: time.Now()}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, rate float64, burst int) (bool, int, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now, rate, burst, false)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		if s.MaxBuckets > 0 && len(s.buckets) >= s.MaxBuckets {
+			s.evict(now, rate, burst)
+		}
+
+		bucket = &tokenBucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(burst), bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		if rate <= 0 {
+			return false, 0, time.Minute, nil
+		}
+
+		wait := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+		return false, 0, wait, nil
+	}
+
+	bucket.tokens--
+
+	return true, int(bucket.tokens), 0, nil
+}
+
+// sweep drops buckets which are refilled to burst, they behave the same as new ones
+func (s *MemoryRateLimitStore) sweep(now time.Time, rate float64, burst int, force bool) {
+	if (!force && now.Sub(s.swept) < time.Minute) || rate <= 0 {
+		return
+	}
+
+	s.swept = now
+	full := time.Duration(float64(burst) / rate * float64(time.Second))
+
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) > full {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// evict makes room for a new bucket when the store is full
+func (s *MemoryRateLimitStore) evict(now time.Time, rate float64, burst int) {
+	if now.Sub(s.swept) >= time.Second {
+		s.sweep(now, rate, burst, true)
+	}
+
+	for key := range s.buckets {
+		if len(s.buckets) < s.MaxBuckets {
+			return
+		}
+		delete(s.buckets, key)
+	}
+}
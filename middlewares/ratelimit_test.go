@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+func TestRateLimitByIPIgnoresForwardedIP(t *testing.T) {
+	metadata := map[string]interface{}{
+		service.MetadataIP:       "198.51.100.1",
+		service.MetadataClientIP: "203.0.113.9",
+	}
+
+	if key := RateLimitByIP(nil, metadata); key != "ip:203.0.113.9" {
+		t.Errorf("expected key by client ip, got %s", key)
+	}
+}
+
+func TestMemoryRateLimitStoreCapsBuckets(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	store.MaxBuckets = 10
+
+	for i := 0; i < 100; i++ {
+		if _, _, _, err := store.Take(fmt.Sprintf("key-%d", i), 1, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(store.buckets) > store.MaxBuckets {
+		t.Errorf("expected at most %d buckets, got %d", store.MaxBuckets, len(store.buckets))
+	}
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/saiset-co/sai-service/service"
+)
+
+// Recovery turns handler panics into 500 errors. As the last global middleware it wraps the handler
+// and all global middlewares, but handler Middlewares run outside of it, so handlers with their own
+// middlewares need it as the last of them too
+type Recovery struct {
+	// Message is returned to the client instead of the panic value
+	Message string
+	// OnPanic is called with the panic value and stack, e.g. to notify a crash reporter
+	OnPanic func(recovered interface{}, stack []byte, metadata interface{})
+
+	panics atomic.Int64
+}
+
+func NewRecovery(message string) *Recovery {
+	if message == "" {
+		message = "internal server error"
+	}
+
+	return &Recovery{Message: message}
+}
+
+// Panics returns the number of recovered panics
+func (r *Recovery) Panics() int64 {
+	return r.panics.Load()
+}
+
+func (r *Recovery) Middleware(next service.HandlerFunc, data interface{}, metadata interface{}) (result interface{}, status int, err error) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		stack := debug.Stack()
+		r.panics.Add(1)
+
+		requestID, _ := service.GetMetadata[string](metadata, service.MetadataRequestID)
+		log.Printf("recovery: panic in handler (request %s): %v\n%s", requestID, recovered, stack)
+
+		if r.OnPanic != nil {
+			r.OnPanic(recovered, stack, metadata)
+		}
+
+		result, status, err = nil, http.StatusInternalServerError, errors.New(r.Message)
+	}()
+
+	return next(data, metadata)
+}
@@ -0,0 +1,73 @@
+// Package redisstore keeps rate limit token buckets in Redis, so limits are shared between
+// service instances. It is a separate module, so services without it don't depend on a Redis client
+package redisstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and takes a token atomically, the Redis clock is used so instances agree on time.
+// Returns allowed (0/1), tokens left and the wait for the next token in milliseconds
+var takeScript = redis.NewScript(`
+redis.replicate_commands()
+
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local allowed = 0
+local wait = 60000
+
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+	wait = 0
+elseif rate > 0 then
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+
+local ttl = 60000
+if rate > 0 then
+	ttl = math.ceil(burst / rate * 1000) + 1000
+end
+redis.call('PEXPIRE', KEYS[1], ttl)
+
+return {allowed, math.floor(tokens), wait}
+`)
+
+// Store implements middlewares.RateLimitStore, buckets expire once they are refilled
+type Store struct {
+	Client redis.Scripter
+	Prefix string
+	// Timeout limits each Redis call, on errors the limiter lets requests through
+	Timeout time.Duration
+}
+
+func New(client redis.Scripter, prefix string) *Store {
+	return &Store{Client: client, Prefix: prefix, Timeout: 100 * time.Millisecond}
+}
+
+func (s *Store) Take(key string, rate float64, burst int) (bool, int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	result, err := takeScript.Run(ctx, s.Client, []string{s.Prefix + key}, rate, burst).Int64Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	return result[0] == 1, int(result[1]), time.Duration(result[2]) * time.Millisecond, nil
+}
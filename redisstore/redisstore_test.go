@@ -0,0 +1,49 @@
+package redisstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/saiset-co/sai-service/middlewares"
+)
+
+var _ middlewares.RateLimitStore = (*Store)(nil)
+
+func TestStoreTake(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	store := New(client, "rate:")
+
+	for i, expected := range []int{1, 0} {
+		allowed, remaining, _, err := store.Take("ip:203.0.113.9", 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !allowed || remaining != expected {
+			t.Errorf("take %d: expected allowed with %d left, got %v %d", i, expected, allowed, remaining)
+		}
+	}
+
+	allowed, _, retryAfter, err := store.Take("ip:203.0.113.9", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if allowed || retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("expected rejection with retry within 1s, got %v %s", allowed, retryAfter)
+	}
+
+	if allowed, _, _, _ := store.Take("ip:198.51.100.7", 1, 2); !allowed {
+		t.Errorf("expected a separate bucket per key")
+	}
+
+	if ttl := server.TTL("rate:ip:203.0.113.9"); ttl <= 0 {
+		t.Errorf("expected the bucket to expire, got ttl %s", ttl)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// protect guards internal endpoints with the settings of common.internal.<group>:
+// api_key (X-API-Key header), basic_auth.user/password, allow_ips (IPs or CIDRs)
+// and use_token (reuse of the common.token check)
+func (s *Service) protect(group string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		prefix := "common.internal." + group
+
+		allowIPs := s.getConfigStrings(prefix + ".allow_ips")
+		if len(allowIPs) > 0 && !ipAllowed(s.clientIP(req), allowIPs) {
+			s.writeError(resp, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		apiKey := s.GetConfig(prefix+".api_key", "").(string)
+		if apiKey != "" && !secureEqual(req.Header.Get("X-API-Key"), apiKey) {
+			s.writeError(resp, http.StatusUnauthorized, "Wrong api key")
+			return
+		}
+
+		user := s.GetConfig(prefix+".basic_auth.user", "").(string)
+		if user != "" {
+			reqUser, reqPassword, ok := req.BasicAuth()
+			password := s.GetConfig(prefix+".basic_auth.password", "").(string)
+			if !ok || !secureEqual(reqUser, user) || !secureEqual(reqPassword, password) {
+				resp.Header().Set("WWW-Authenticate", `Basic realm="`+s.Name+`"`)
+				s.writeError(resp, http.StatusUnauthorized, "Wrong credentials")
+				return
+			}
+		}
+
+		token := s.GetConfig("common.token", "").(string)
+		if s.GetConfig(prefix+".use_token", false).(bool) && token != "" && !secureEqual(req.Header.Get("Token"), token) {
+			s.writeError(resp, http.StatusUnauthorized, "Wrong token")
+			return
+		}
+
+		next.ServeHTTP(resp, req)
+	})
+}
+
+// requireToken rejects requests without the common.token in the Token header, like the handler endpoint
+func (s *Service) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		token := s.GetConfig("common.token", "").(string)
+		if token != "" && !secureEqual(req.Header.Get("Token"), token) {
+			s.writeError(resp, http.StatusUnauthorized, "Wrong token")
+			return
+		}
+
+		next.ServeHTTP(resp, req)
+	})
+}
+
+// clientIP returns the connection address. X-Real-IP and X-Forwarded-For are honoured only
+// when the connection comes from common.trusted_proxies (IPs or CIDRs)
+func (s *Service) clientIP(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil || net.ParseIP(ip) == nil {
+		return ""
+	}
+
+	trusted := s.getConfigStrings("common.trusted_proxies")
+	if len(trusted) == 0 || !ipAllowed(ip, trusted) {
+		return ip
+	}
+
+	if realIP := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	// The rightmost address which is not a trusted proxy is the client
+	forwarded := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+
+		ip = hop
+		if !ipAllowed(hop, trusted) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// isProtected reports whether any protection is configured for the internal endpoints group
+func (s *Service) isProtected(group string) bool {
+	prefix := "common.internal." + group
+
+	return len(s.getConfigStrings(prefix+".allow_ips")) > 0 ||
+		s.GetConfig(prefix+".api_key", "").(string) != "" ||
+		s.GetConfig(prefix+".basic_auth.user", "").(string) != "" ||
+		(s.GetConfig(prefix+".use_token", false).(bool) && s.GetConfig("common.token", "").(string) != "")
+}
+
+func (s *Service) writeError(resp http.ResponseWriter, status int, message string) {
+	err := s.errorBody(errors.New(message), status, nil)
+	errBody, _ := json.Marshal(err)
+	log.Println(err)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	resp.Write(errBody)
+}
+
+func (s *Service) getConfigStrings(path string) []string {
+	var result []string
+
+	switch values := s.GetConfig(path, nil).(type) {
+	case []interface{}:
+		for _, value := range values {
+			if str, ok := value.(string); ok {
+				result = append(result, str)
+			}
+		}
+	case string:
+		for _, value := range strings.Split(values, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				result = append(result, value)
+			}
+		}
+	}
+
+	return result
+}
+
+func ipAllowed(ip string, allowed []string) bool {
+	netIP := net.ParseIP(ip)
+	if netIP == nil {
+		return false
+	}
+
+	for _, item := range allowed {
+		if strings.Contains(item, "/") {
+			_, network, err := net.ParseCIDR(item)
+			if err == nil && network.Contains(netIP) {
+				return true
+			}
+			continue
+		}
+
+		if allowedIP := net.ParseIP(item); allowedIP != nil && allowedIP.Equal(netIP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
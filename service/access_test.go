@@ -0,0 +1,87 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestService(configuration map[string]interface{}) *Service {
+	s := &Service{Name: "test", Context: NewContext()}
+	s.Context.SetConfiguration(configuration)
+
+	return s
+}
+
+func TestProtectIgnoresSpoofedForwardingHeaders(t *testing.T) {
+	s := newTestService(map[string]interface{}{
+		"common": map[string]interface{}{
+			"internal": map[string]interface{}{
+				"health": map[string]interface{}{"allow_ips": []interface{}{"127.0.0.1"}},
+			},
+		},
+	})
+
+	handler := s.protect("health", http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+	}))
+
+	for _, header := range []string{"X-Real-IP", "X-Forwarded-For"} {
+		req := httptest.NewRequest(http.MethodGet, "/check", nil)
+		req.RemoteAddr = "203.0.113.9:51000"
+		req.Header.Set(header, "127.0.0.1")
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+
+		if resp.Code != http.StatusForbidden {
+			t.Errorf("%s spoofing: expected %d, got %d", header, http.StatusForbidden, resp.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/check", nil)
+	req.RemoteAddr = "127.0.0.1:51000"
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("allowed address: expected %d, got %d", http.StatusOK, resp.Code)
+	}
+}
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	s := newTestService(map[string]interface{}{
+		"common": map[string]interface{}{
+			"trusted_proxies": []interface{}{"10.0.0.0/8"},
+		},
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		forwarded  string
+		expected   string
+	}{
+		{"untrusted peer", "203.0.113.9:1000", "127.0.0.1", "127.0.0.1", "203.0.113.9"},
+		{"real ip from proxy", "10.0.0.5:1000", "198.51.100.7", "", "198.51.100.7"},
+		{"forwarded chain", "10.0.0.5:1000", "", "127.0.0.1, 198.51.100.7, 10.0.0.6", "198.51.100.7"},
+		{"proxy without headers", "10.0.0.5:1000", "", "", "10.0.0.5"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remoteAddr
+		if test.realIP != "" {
+			req.Header.Set("X-Real-IP", test.realIP)
+		}
+		if test.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+
+		if ip := s.clientIP(req); ip != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, ip)
+		}
+	}
+}
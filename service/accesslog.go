@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+type accessLogger struct {
+	format     string
+	sampleRate float64
+	exclude    map[string]bool
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+type accessLogRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *accessLogRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *accessLogRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (r *accessLogRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *accessLogRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// accessLog wraps the handler with the common.access_log settings: format (common, combined, json),
+// output (stdout, stderr or file path), sample_rate (0..1) and exclude (paths, /check by default)
+func (s *Service) accessLog(next http.Handler) http.Handler {
+	s.accessLogOnce.Do(func() {
+		if !s.GetConfig("common.access_log.enabled", false).(bool) {
+			return
+		}
+
+		logger, err := s.newAccessLogger()
+		if err != nil {
+			log.Println("Access log error: ", err)
+			return
+		}
+
+		s.accessLogger = logger
+	})
+
+	if s.accessLogger == nil {
+		return next
+	}
+
+	return s.accessLogger.wrap(s, next)
+}
+
+func (s *Service) newAccessLogger() (*accessLogger, error) {
+	logger := &accessLogger{
+		format:     s.GetConfig("common.access_log.format", "combined").(string),
+		sampleRate: 1,
+		exclude:    map[string]bool{},
+	}
+
+	switch logger.format {
+	case "common", "combined", "json":
+	default:
+		return nil, fmt.Errorf("unknown access log format %q", logger.format)
+	}
+
+	switch rate := s.GetConfig("common.access_log.sample_rate", 1.0).(type) {
+	case float64:
+		logger.sampleRate = rate
+	case int:
+		logger.sampleRate = float64(rate)
+	}
+
+	exclude := s.getConfigStrings("common.access_log.exclude")
+	if s.GetConfig("common.access_log.exclude", nil) == nil {
+		exclude = []string{"/check"}
+	}
+	for _, path := range exclude {
+		logger.exclude[path] = true
+	}
+
+	switch output := s.GetConfig("common.access_log.output", "stdout").(string); output {
+	case "stdout":
+		logger.out = os.Stdout
+	case "stderr":
+		logger.out = os.Stderr
+	default:
+		file, err := os.OpenFile(output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		logger.out = file
+	}
+
+	return logger, nil
+}
+
+func (l *accessLogger) wrap(s *Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if l.exclude[req.URL.Path] || (l.sampleRate < 1 && rand.Float64() >= l.sampleRate) {
+			next.ServeHTTP(resp, req)
+			return
+		}
+
+		started := time.Now()
+		recorder := &accessLogRecorder{ResponseWriter: resp}
+
+		next.ServeHTTP(recorder, req)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		l.write(s.clientIP(req), req, recorder, started)
+	})
+}
+
+func (l *accessLogger) write(ip string, req *http.Request, recorder *accessLogRecorder, started time.Time) {
+	user := "-"
+	if username, _, ok := req.BasicAuth(); ok && username != "" {
+		user = username
+	}
+
+	var line []byte
+
+	switch l.format {
+	case "json":
+		line, _ = json.Marshal(map[string]interface{}{
+			"time":        started.Format(time.RFC3339),
+			"remote":      ip,
+			"user":        user,
+			"method":      req.Method,
+			"path":        req.URL.RequestURI(),
+			"proto":       req.Proto,
+			"status":      recorder.status,
+			"bytes":       recorder.bytes,
+			"duration_ms": durationMs(time.Since(started)),
+			"referer":     req.Referer(),
+			"user_agent":  req.UserAgent(),
+			"request_id":  recorder.Header().Get("X-Request-ID"),
+		})
+	default:
+		size := "-"
+		if recorder.bytes > 0 {
+			size = strconv.Itoa(recorder.bytes)
+		}
+
+		line = []byte(fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+			orDash(ip), user, started.Format(accessLogTimeFormat),
+			req.Method, req.URL.RequestURI(), req.Proto, recorder.status, size))
+
+		if l.format == "combined" {
+			line = append(line, fmt.Sprintf(" %q %q", req.Referer(), req.UserAgent())...)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.out.Write(append(line, '\n'))
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
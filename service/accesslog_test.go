@@ -0,0 +1,39 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogUsesConnectionAddress(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "access.log")
+
+	s := newTestService(map[string]interface{}{
+		"common": map[string]interface{}{
+			"access_log": map[string]interface{}{"enabled": true, "format": "common", "output": logPath},
+		},
+	})
+
+	handler := s.accessLog(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	req.RemoteAddr = "203.0.113.9:51000"
+	req.Header.Set("X-Real-IP", "127.0.0.1")
+	req.Header.Set("X-Forwarded-For", "127.0.0.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	accessLog, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(accessLog), "203.0.113.9 ") {
+		t.Errorf("expected the connection address, got %s", accessLog)
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+)
+
+func (s *Service) adminEnabled() bool {
+	return s.GetConfig("common.admin.enabled", false).(bool)
+}
+
+// registerOperational mounts health, version, startup report, routes and profiling endpoints
+func (s *Service) registerOperational(r *http.ServeMux) {
+	r.Handle("/check", s.allowMethods(readMethods, s.protect("health", http.HandlerFunc(s.healthCheck))))
+	r.Handle("/version", s.allowMethods(readMethods, s.protect("version", http.HandlerFunc(s.versionCheck))))
+
+	if s.GetConfig("common.startup.endpoint", false).(bool) {
+		r.Handle("/admin/startup", s.allowMethods(readMethods, s.protect("admin", http.HandlerFunc(s.startupCheck))))
+	}
+
+	if s.GetConfig("common.routes.endpoint", false).(bool) {
+		r.Handle("/admin/routes", s.allowMethods(readMethods, s.protect("admin", http.HandlerFunc(s.routesCheck))))
+	}
+
+	if s.GetConfig("common.profiling.port", 0).(int) == 0 {
+		s.registerProfiling(r)
+	}
+}
+
+// listenAdmin serves operational endpoints and Admin handlers on a separate port/interface,
+// so they are never exposed by the public listeners
+func (s *Service) listenAdmin() (*http.Server, net.Listener, error) {
+	host := s.GetConfig("common.admin.host", "127.0.0.1").(string)
+	port := s.GetConfig("common.admin.port", 8090).(int)
+
+	r := http.NewServeMux()
+
+	var handler http.Handler = http.HandlerFunc(s.handleAdminHttpConnections)
+	if methods := s.rpcMethods(); len(methods) > 0 {
+		handler = s.allowMethods(methods, handler)
+	}
+
+	r.Handle("/", handler)
+	s.registerOperational(r)
+
+	server := &http.Server{Addr: net.JoinHostPort(host, strconv.Itoa(port)), Handler: s.accessLog(s.trackRequests(s.limitInFlight("common.admin", r)))}
+
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.addServer(server)
+
+	return server, ln, nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError holds per-field messages, transports return it as 400 with the Fields key
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, message := range e.Fields {
+		fields = append(fields, field+" "+message)
+	}
+	sort.Strings(fields)
+
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// Bind decodes handler data into v (pointer to struct) and checks its validate tags:
+// required, min=N, max=N (length for strings, slices and maps) and oneof=a b c
+func Bind(data interface{}, v interface{}) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(dataBytes, v); err != nil {
+		return &ValidationError{Fields: map[string]string{"data": err.Error()}}
+	}
+
+	return Validate(v)
+}
+
+// Validate checks the validate tags of v (struct or pointer to struct) and its nested structs,
+// failed fields are reported by json name with dots for nesting, e.g. "address.city"
+func Validate(v interface{}) error {
+	fields := map[string]string{}
+	validateStruct(reflect.ValueOf(v), "", fields)
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+
+	return nil
+}
+
+// bindRequest wraps the handler function so data is bound into a new instance of the request prototype
+func bindRequest(request interface{}, next HandlerFunc) HandlerFunc {
+	requestType := reflect.TypeOf(request)
+	if requestType.Kind() == reflect.Ptr {
+		requestType = requestType.Elem()
+	}
+
+	return func(data interface{}, metadata interface{}) (interface{}, int, error) {
+		req := reflect.New(requestType).Interface()
+
+		if err := Bind(data, req); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+
+		return next(req, metadata)
+	}
+}
+
+func validateStruct(value reflect.Value, prefix string, fields map[string]string) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		name = prefix + name
+
+		fieldValue := value.Field(i)
+
+		if tag := field.Tag.Get("validate"); tag != "" {
+			if message := validateField(fieldValue, tag); message != "" {
+				fields[name] = message
+				continue
+			}
+		}
+
+		validateStruct(fieldValue, name+".", fields)
+	}
+}
+
+func validateField(value reflect.Value, tag string) string {
+	for _, rule := range strings.Split(tag, ",") {
+		ruleName, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch ruleName {
+		case "required":
+			if value.IsZero() {
+				return "is required"
+			}
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+
+			size, ok := measure(value)
+			if !ok {
+				continue
+			}
+
+			if ruleName == "min" && size < limit {
+				return "must be at least " + arg
+			}
+
+			if ruleName == "max" && size > limit {
+				return "must be at most " + arg
+			}
+		case "oneof":
+			if value.IsZero() {
+				continue
+			}
+
+			actual := fmt.Sprint(reflect.Indirect(value).Interface())
+
+			found := false
+			for _, option := range strings.Fields(arg) {
+				if option == actual {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return "must be one of " + arg
+			}
+		}
+	}
+
+	return ""
+}
+
+func measure(value reflect.Value) (float64, bool) {
+	value = reflect.Indirect(value)
+
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+
+	return 0, false
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
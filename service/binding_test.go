@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type testRequest struct {
+	Name     string            `json:"name" validate:"required,min=2,max=5"`
+	Age      int               `json:"age" validate:"min=18,max=99"`
+	Role     string            `json:"role" validate:"oneof=admin user"`
+	Nickname *string           `json:"nickname" validate:"oneof=neo trinity"`
+	Tags     []string          `json:"tags" validate:"max=2"`
+	Labels   map[string]string `json:"labels" validate:"min=1"`
+	Address  testAddress       `json:"address"`
+	Billing  *testAddress      `json:"billing,omitempty"`
+	Ignored  string            `json:"-" validate:"required"`
+	NoTag    string            `validate:"required"`
+}
+
+func validTestRequest() map[string]interface{} {
+	return map[string]interface{}{
+		"name":    "bob",
+		"age":     30,
+		"role":    "user",
+		"tags":    []string{"a"},
+		"labels":  map[string]string{"team": "core"},
+		"address": map[string]interface{}{"city": "Riga"},
+		"NoTag":   "set",
+	}
+}
+
+func TestBindValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(data map[string]interface{})
+		expected map[string]string
+	}{
+		{"valid", func(data map[string]interface{}) {}, nil},
+		{"required", func(data map[string]interface{}) { delete(data, "name") }, map[string]string{"name": "is required"}},
+		{"min length", func(data map[string]interface{}) { data["name"] = "b" }, map[string]string{"name": "must be at least 2"}},
+		{"max length", func(data map[string]interface{}) { data["name"] = "robert" }, map[string]string{"name": "must be at most 5"}},
+		{"min number", func(data map[string]interface{}) { data["age"] = 17 }, map[string]string{"age": "must be at least 18"}},
+		{"max number", func(data map[string]interface{}) { data["age"] = 100 }, map[string]string{"age": "must be at most 99"}},
+		{"max slice", func(data map[string]interface{}) { data["tags"] = []string{"a", "b", "c"} }, map[string]string{"tags": "must be at most 2"}},
+		{"min map", func(data map[string]interface{}) { delete(data, "labels") }, map[string]string{"labels": "must be at least 1"}},
+		{"oneof", func(data map[string]interface{}) { data["role"] = "root" }, map[string]string{"role": "must be one of admin user"}},
+		{"oneof empty is skipped", func(data map[string]interface{}) { delete(data, "role") }, nil},
+		{"oneof pointer", func(data map[string]interface{}) { data["nickname"] = "smith" }, map[string]string{"nickname": "must be one of neo trinity"}},
+		{"oneof nil pointer", func(data map[string]interface{}) { data["nickname"] = nil }, nil},
+		{"nested field name", func(data map[string]interface{}) { data["address"] = map[string]interface{}{} }, map[string]string{"address.city": "is required"}},
+		{"nested nil pointer", func(data map[string]interface{}) { data["billing"] = nil }, nil},
+		{"nested pointer", func(data map[string]interface{}) { data["billing"] = map[string]interface{}{} }, map[string]string{"billing.city": "is required"}},
+		{"go field name without json tag", func(data map[string]interface{}) { delete(data, "NoTag") }, map[string]string{"NoTag": "is required"}},
+		{"wrong type", func(data map[string]interface{}) { data["age"] = "old" }, nil},
+	}
+
+	for _, test := range tests {
+		data := validTestRequest()
+		test.change(data)
+
+		err := Bind(data, &testRequest{})
+
+		if test.name == "wrong type" {
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Fields["data"] == "" {
+				t.Errorf("%s: expected data error, got %v", test.name, err)
+			}
+			continue
+		}
+
+		if test.expected == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: expected ValidationError, got %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(validationErr.Fields, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, validationErr.Fields)
+		}
+	}
+}
+
+func TestValidateNilPointer(t *testing.T) {
+	var request *testRequest
+
+	if err := Validate(request); err != nil {
+		t.Errorf("expected nil pointer to be skipped, got %v", err)
+	}
+}
+
+func TestRequestBindingThroughMiddleware(t *testing.T) {
+	s := newTestService(map[string]interface{}{})
+
+	var bound *testRequest
+	handler := HandlerElement{
+		Name:    "create",
+		Request: testRequest{},
+		Function: func(data, metadata interface{}) (interface{}, int, error) {
+			bound = data.(*testRequest)
+			return "ok", http.StatusOK, nil
+		},
+	}
+
+	_, status, err := s.applyMiddleware(handler, map[string]interface{}{"name": "b"}, map[string]interface{}{})
+	if status != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d", http.StatusBadRequest, status)
+	}
+
+	body := newErrorResponse(err)
+	fields, ok := body["Fields"].(map[string]string)
+	if !ok || fields["name"] != "must be at least 2" || fields["address.city"] != "is required" {
+		t.Errorf("unexpected error body %v", body)
+	}
+
+	if bound != nil {
+		t.Errorf("handler was called with invalid data")
+	}
+
+	_, status, err = s.applyMiddleware(handler, validTestRequest(), map[string]interface{}{})
+	if err != nil || status != http.StatusOK || bound == nil || bound.Name != "bob" {
+		t.Errorf("expected bound request, got %d %v %+v", status, err, bound)
+	}
+}
@@ -3,11 +3,14 @@ package service
 import (
 	"context"
 	"strings"
+	"sync"
 )
 
 type Context struct {
 	Configuration map[string]interface{}
 	Context       context.Context
+
+	mu sync.RWMutex
 }
 
 func NewContext() *Context {
@@ -21,7 +24,17 @@ func (c *Context) SetValue(key string, value interface{}) {
 	c.Context = context.WithValue(context.Background(), key, value)
 }
 
+func (c *Context) SetConfiguration(configuration map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Configuration = configuration
+}
+
 func (c *Context) GetConfig(path string, def interface{}) any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	steps := strings.Split(path, ".")
 	configuration := c.Configuration
 
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	ConfigKeyEnv    = "SAI_CONFIG_KEY"
+	encryptedPrefix = "ENC["
+	encryptedSuffix = "]"
+)
+
+// ConfigKey reads base64 encoded 32 bytes AES-256 key from the SAI_CONFIG_KEY env
+func ConfigKey() ([]byte, error) {
+	raw := os.Getenv(ConfigKeyEnv)
+	if raw == "" {
+		return nil, fmt.Errorf("%s is not set", ConfigKeyEnv)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("wrong %s: %w", ConfigKeyEnv, err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("wrong %s: key must be 32 bytes, got %d", ConfigKeyEnv, len(key))
+	}
+
+	return key, nil
+}
+
+// EncryptValue returns value sealed with AES-GCM in the ENC[...] form accepted by the config loader
+func EncryptValue(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(value), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+func DecryptValue(key []byte, value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encryptedPrefix), encryptedSuffix))
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) && strings.HasSuffix(value, encryptedSuffix)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func decryptConfiguration(configuration map[string]interface{}) error {
+	var key []byte
+
+	var decrypt func(value interface{}, path string) (interface{}, error)
+	decrypt = func(value interface{}, path string) (interface{}, error) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for k, item := range v {
+				res, err := decrypt(item, strings.TrimPrefix(path+"."+k, "."))
+				if err != nil {
+					return nil, err
+				}
+				v[k] = res
+			}
+		case []interface{}:
+			for i, item := range v {
+				res, err := decrypt(item, fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return nil, err
+				}
+				v[i] = res
+			}
+		case string:
+			if !isEncrypted(v) {
+				return v, nil
+			}
+
+			if key == nil {
+				var err error
+				if key, err = ConfigKey(); err != nil {
+					return nil, err
+				}
+			}
+
+			plain, err := DecryptValue(key, v)
+			if err != nil {
+				return nil, fmt.Errorf("can't decrypt %s: %w", path, err)
+			}
+
+			return plain, nil
+		}
+
+		return value, nil
+	}
+
+	_, err := decrypt(configuration, "")
+
+	return err
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+type drainState struct {
+	draining atomic.Bool
+	inFlight atomic.Int64
+}
+
+// Draining reports whether the service is stopping and asks clients to close connections
+func (s *Service) Draining() bool {
+	return s.drain.draining.Load()
+}
+
+// InFlight returns the number of HTTP requests being processed
+func (s *Service) InFlight() int64 {
+	return s.drain.inFlight.Load()
+}
+
+// trackRequests counts in-flight requests and sends Connection: close while draining,
+// so keep-alive clients reconnect to other instances
+func (s *Service) trackRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		s.drain.inFlight.Add(1)
+		defer s.drain.inFlight.Add(-1)
+
+		if s.Draining() {
+			resp.Header().Set("Connection", "close")
+		}
+
+		next.ServeHTTP(resp, req)
+	})
+}
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"golang.org/x/net/websocket"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Handler map[string]HandlerElement
@@ -21,6 +24,10 @@ type HandlerElement struct {
 	Description string
 	Function    HandlerFunc
 	Middlewares []Middleware
+	// Admin handlers are served only by the admin listener and CLI when common.admin is enabled
+	Admin bool
+	// Request is a struct prototype, data is bound into a new instance of it and validated before Function
+	Request interface{}
 }
 
 type HandlerFunc = func(interface{}, interface{}) (interface{}, int, error)
@@ -33,6 +40,56 @@ type JsonRequestType struct {
 
 type ErrorResponse map[string]interface{}
 
+func newErrorResponse(err error) ErrorResponse {
+	response := ErrorResponse{"Status": "NOK", "Error": err.Error()}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		response["Fields"] = validationErr.Fields
+	}
+
+	return response
+}
+
+// ErrorHandler builds the error body returned by all transports, e.g. a branded envelope
+type ErrorHandler func(err error, status int, metadata map[string]interface{}) interface{}
+
+func (s *Service) SetErrorHandler(handler ErrorHandler) {
+	s.ErrorHandler = handler
+}
+
+// SetNotFoundHandler sets the function called for unknown methods instead of the 404 error
+func (s *Service) SetNotFoundHandler(handler HandlerFunc) {
+	s.NotFoundHandler = handler
+}
+
+func (s *Service) errorBody(err error, status int, metadata map[string]interface{}) interface{} {
+	if s.ErrorHandler != nil {
+		return s.ErrorHandler(err, status, metadata)
+	}
+
+	response := newErrorResponse(err)
+	if requestID, ok := metadata[MetadataRequestID]; ok {
+		response["RequestID"] = requestID
+	}
+
+	return response
+}
+
+// RetryAfterError makes the HTTP transport add the Retry-After header to the error response
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
 func (s *Service) handleSocketConnections(conn net.Conn) {
 	for {
 		var message JsonRequestType
@@ -42,17 +99,19 @@ func (s *Service) handleSocketConnections(conn net.Conn) {
 			_ = json.Unmarshal([]byte(socketMessage), &message)
 
 			if message.Method == "" {
-				err := ErrorResponse{"Status": "NOK", "Error": "Wrong message format"}
+				err := s.errorBody(errors.New("Wrong message format"), http.StatusBadRequest, nil)
 				errBody, _ := json.Marshal(err)
 				log.Println(err)
 				conn.Write(append(errBody, eos...))
 				continue
 			}
 
-			result, _, resultErr := s.processPath(&message)
+			setSocketMetadata(&message, conn)
+
+			result, status, resultErr := s.processPath(&message, false)
 
 			if resultErr != nil {
-				err := ErrorResponse{"Status": "NOK", "Error": resultErr.Error()}
+				err := s.errorBody(resultErr, status, message.Metadata)
 				errBody, _ := json.Marshal(err)
 				log.Println(err)
 				conn.Write(append(errBody, eos...))
@@ -62,7 +121,7 @@ func (s *Service) handleSocketConnections(conn net.Conn) {
 			body, marshalErr := json.Marshal(result)
 
 			if marshalErr != nil {
-				err := ErrorResponse{"Status": "NOK", "Error": marshalErr.Error()}
+				err := s.errorBody(marshalErr, http.StatusInternalServerError, message.Metadata)
 				errBody, _ := json.Marshal(err)
 				log.Println(err)
 				conn.Write(append(errBody, eos...))
@@ -74,6 +133,23 @@ func (s *Service) handleSocketConnections(conn net.Conn) {
 	}
 }
 
+// setSocketMetadata fills the metadata like the other transports, the socket has no forwarding
+// headers, so ip and client_ip are both the connection address
+func setSocketMetadata(message *JsonRequestType, conn net.Conn) {
+	if message.Metadata == nil {
+		message.Metadata = map[string]interface{}{}
+	}
+
+	stripReservedMetadata(message.Metadata)
+
+	if ip, _, err := net.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+		message.Metadata[MetadataIP] = ip
+		message.Metadata[MetadataClientIP] = ip
+	}
+
+	message.Metadata[MetadataRequestID] = newRequestID()
+}
+
 // handle cli command
 func (s *Service) handleCliCommand(data []byte) ([]byte, error) {
 
@@ -92,7 +168,7 @@ func (s *Service) handleCliCommand(data []byte) ([]byte, error) {
 
 	}
 
-	result, _, err := s.processPath(&message)
+	result, _, err := s.processPath(&message, true)
 	if err != nil {
 		return nil, err
 	}
@@ -109,34 +185,44 @@ func (s *Service) handleWSConnections(conn *websocket.Conn) {
 	for {
 		var message JsonRequestType
 		if rErr := websocket.JSON.Receive(conn, &message); rErr != nil {
-			err := ErrorResponse{"Status": "NOK", "Error": "Wrong message format"}
+			err := s.errorBody(errors.New("Wrong message format"), http.StatusBadRequest, nil)
 			log.Println(err)
 			websocket.JSON.Send(conn, err)
 			continue
 		}
 
 		if message.Method == "" {
-			err := ErrorResponse{"Status": "NOK", "Error": "Wrong message format"}
+			err := s.errorBody(errors.New("Wrong message format"), http.StatusBadRequest, nil)
 			log.Println(err)
 			websocket.JSON.Send(conn, err)
 			continue
 		}
 
+		if message.Metadata == nil {
+			message.Metadata = map[string]interface{}{}
+		}
+
+		stripReservedMetadata(message.Metadata)
+
+		message.Metadata[MetadataIP] = s.getHttpIP(conn.Request())
+		message.Metadata[MetadataClientIP] = s.clientIP(conn.Request())
+		message.Metadata[MetadataRequestID] = newRequestID()
+
 		headers := conn.Request().Header
 		token := headers.Get("Token")
 		if s.GetConfig("token", "").(string) != "" {
 			if token != s.GetConfig("token", "") {
-				err := ErrorResponse{"Status": "NOK", "Error": "Wrong token"}
+				err := s.errorBody(errors.New("Wrong token"), http.StatusUnauthorized, message.Metadata)
 				log.Println(err)
 				websocket.JSON.Send(conn, err)
 				continue
 			}
 		}
 
-		result, _, resultErr := s.processPath(&message)
+		result, status, resultErr := s.processPath(&message, false)
 
 		if resultErr != nil {
-			err := ErrorResponse{"Status": "NOK", "Error": resultErr.Error()}
+			err := s.errorBody(resultErr, status, message.Metadata)
 			log.Println(err)
 			websocket.JSON.Send(conn, err)
 			continue
@@ -145,21 +231,13 @@ func (s *Service) handleWSConnections(conn *websocket.Conn) {
 		sErr := websocket.JSON.Send(conn, result)
 
 		if sErr != nil {
-			err := ErrorResponse{"Status": "NOK", "Error": sErr.Error()}
+			err := s.errorBody(sErr, http.StatusInternalServerError, message.Metadata)
 			log.Println(err)
 			websocket.JSON.Send(conn, err)
 		}
 	}
 }
 
-func (s *Service) healthCheck(resp http.ResponseWriter, req *http.Request) {
-	data := map[string]interface{}{"Status": "OK"}
-	body, _ := json.Marshal(data)
-	resp.WriteHeader(http.StatusOK)
-	resp.Write(body)
-	return
-}
-
 func (s *Service) versionCheck(resp http.ResponseWriter, req *http.Request) {
 	data := map[string]interface{}{
 		"Version": s.GetConfig("common.version", "0.1").(string),
@@ -172,6 +250,14 @@ func (s *Service) versionCheck(resp http.ResponseWriter, req *http.Request) {
 }
 
 func (s *Service) handleHttpConnections(resp http.ResponseWriter, req *http.Request) {
+	s.serveHttpConnections(resp, req, false)
+}
+
+func (s *Service) handleAdminHttpConnections(resp http.ResponseWriter, req *http.Request) {
+	s.serveHttpConnections(resp, req, true)
+}
+
+func (s *Service) serveHttpConnections(resp http.ResponseWriter, req *http.Request, admin bool) {
 	var message JsonRequestType
 	decoder := json.NewDecoder(req.Body)
 	decoderErr := decoder.Decode(&message)
@@ -179,25 +265,40 @@ func (s *Service) handleHttpConnections(resp http.ResponseWriter, req *http.Requ
 		message.Metadata = map[string]interface{}{}
 	}
 
-	message.Metadata["ip"] = s.getHttpIP(req)
+	stripReservedMetadata(message.Metadata)
+
+	message.Metadata[MetadataIP] = s.getHttpIP(req)
+	message.Metadata[MetadataClientIP] = s.clientIP(req)
+
+	if fields := req.URL.Query().Get("fields"); fields != "" {
+		message.Metadata[MetadataFields] = fields
+	}
+
+	requestID := req.Header.Get("X-Request-ID")
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	message.Metadata[MetadataRequestID] = requestID
+	message.Metadata[MetadataResponseHeaders] = resp.Header()
 
 	resp.Header().Set("Content-Type", "application/json")
+	resp.Header().Set("X-Request-ID", requestID)
 
-	if decoderErr != nil {
-		err := ErrorResponse{"Status": "NOK", "Error": decoderErr.Error()}
-		errBody, _ := json.Marshal(err)
-		log.Println(err)
-		resp.WriteHeader(http.StatusBadRequest)
+	writeError := func(status int, err error) {
+		body := s.errorBody(err, status, message.Metadata)
+		errBody, _ := json.Marshal(body)
+		log.Println(body)
+		resp.WriteHeader(status)
 		resp.Write(errBody)
+	}
+
+	if decoderErr != nil {
+		writeError(http.StatusBadRequest, decoderErr)
 		return
 	}
 
 	if message.Method == "" {
-		err := ErrorResponse{"Status": "NOK", "Error": "Wrong message format"}
-		errBody, _ := json.Marshal(err)
-		log.Println(err)
-		resp.WriteHeader(http.StatusBadRequest)
-		resp.Write(errBody)
+		writeError(http.StatusBadRequest, errors.New("Wrong message format"))
 		return
 	}
 
@@ -205,33 +306,27 @@ func (s *Service) handleHttpConnections(resp http.ResponseWriter, req *http.Requ
 	token := headers.Get("Token")
 	if s.GetConfig("common.token", "").(string) != "" {
 		if token != s.GetConfig("common.token", "") {
-			err := ErrorResponse{"Status": "NOK", "Error": "Wrong token"}
-			errBody, _ := json.Marshal(err)
-			log.Println(err)
-			resp.WriteHeader(http.StatusUnauthorized)
-			resp.Write(errBody)
+			writeError(http.StatusUnauthorized, errors.New("Wrong token"))
+			return
 		}
 	}
 
-	result, statusCode, resultErr := s.processPath(&message)
+	result, statusCode, resultErr := s.processPath(&message, admin)
 
 	if resultErr != nil {
-		err := ErrorResponse{"Status": "NOK", "Error": resultErr.Error()}
-		errBody, _ := json.Marshal(err)
-		log.Println(err)
-		resp.WriteHeader(statusCode)
-		resp.Write(errBody)
+		var retryErr *RetryAfterError
+		if errors.As(resultErr, &retryErr) {
+			resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryErr.After.Seconds()))))
+		}
+
+		writeError(statusCode, resultErr)
 		return
 	}
 
 	body, marshalErr := json.Marshal(result)
 
 	if marshalErr != nil {
-		err := ErrorResponse{"Status": "NOK", "Error": marshalErr.Error()}
-		errBody, _ := json.Marshal(err)
-		log.Println(err)
-		resp.WriteHeader(http.StatusInternalServerError)
-		resp.Write(errBody)
+		writeError(http.StatusInternalServerError, marshalErr)
 		return
 	}
 	resp.WriteHeader(statusCode)
@@ -242,6 +337,10 @@ func (s *Service) applyMiddleware(handler HandlerElement, data interface{}, meta
 	closures := make([]HandlerFunc, len(s.Middlewares)+len(handler.Middlewares)+1)
 	closures[0] = handler.Function
 
+	if handler.Request != nil {
+		closures[0] = bindRequest(handler.Request, handler.Function)
+	}
+
 	// Function to create a closure for the middleware with the correct next function
 	createMiddlewareClosure := func(middleware Middleware, next HandlerFunc) HandlerFunc {
 		return func(data interface{}, metadata interface{}) (interface{}, int, error) {
@@ -268,10 +367,14 @@ func (s *Service) applyMiddleware(handler HandlerElement, data interface{}, meta
 	return last(data, metadata)
 }
 
-func (s *Service) processPath(msg *JsonRequestType) (interface{}, int, error) {
+func (s *Service) processPath(msg *JsonRequestType, admin bool) (interface{}, int, error) {
 	h, ok := s.Handlers[msg.Method]
 
-	if !ok {
+	if !ok || (h.Admin && !admin && s.adminEnabled()) {
+		if s.NotFoundHandler != nil {
+			return s.NotFoundHandler(msg.Data, msg.Metadata)
+		}
+
 		return nil, http.StatusNotFound, errors.New("no handler")
 	}
 
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpTransportDropsReservedMetadata(t *testing.T) {
+	s := newTestService(map[string]interface{}{})
+
+	var metadata map[string]interface{}
+	s.Handlers = Handler{
+		"get": {Name: "get", Function: func(data, meta interface{}) (interface{}, int, error) {
+			metadata = meta.(map[string]interface{})
+			return "ok", http.StatusOK, nil
+		}},
+	}
+
+	body := `{"method":"get","metadata":{"principal":"admin","tenant":"other","country":"US","asn":1,"client_ip":"127.0.0.1","custom":"kept"}}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.9:51000"
+
+	s.handleHttpConnections(httptest.NewRecorder(), req)
+
+	for _, key := range []string{MetadataPrincipal, MetadataTenant, MetadataCountry, MetadataASN} {
+		if _, ok := metadata[key]; ok {
+			t.Errorf("reserved key %s reached the handler", key)
+		}
+	}
+
+	if ip, _ := GetMetadata[string](metadata, MetadataClientIP); ip != "203.0.113.9" {
+		t.Errorf("expected client ip 203.0.113.9, got %s", ip)
+	}
+
+	if custom, _ := GetMetadata[string](metadata, "custom"); custom != "kept" {
+		t.Errorf("expected custom metadata to be kept, got %q", custom)
+	}
+}
+
+func TestSocketMetadata(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	message := JsonRequestType{Method: "get", Metadata: map[string]interface{}{
+		MetadataRequestID: "client-id",
+		MetadataPrincipal: "admin",
+	}}
+	setSocketMetadata(&message, conn)
+
+	if requestID, _ := GetMetadata[string](message.Metadata, MetadataRequestID); requestID == "" || requestID == "client-id" {
+		t.Errorf("expected generated request id, got %q", requestID)
+	}
+
+	if ip, _ := GetMetadata[string](message.Metadata, MetadataIP); ip != "127.0.0.1" {
+		t.Errorf("expected ip 127.0.0.1, got %q", ip)
+	}
+
+	if ip, _ := GetMetadata[string](message.Metadata, MetadataClientIP); ip != "127.0.0.1" {
+		t.Errorf("expected client ip 127.0.0.1, got %q", ip)
+	}
+
+	if _, ok := message.Metadata[MetadataPrincipal]; ok {
+		t.Errorf("reserved key principal reached the handler")
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type HealthCriticality string
+
+const (
+	// HealthCritical check failure makes the service not ready
+	HealthCritical HealthCriticality = "critical"
+	// HealthDegradedOk check failure marks the service as degraded
+	HealthDegradedOk HealthCriticality = "degraded-ok"
+	// HealthInformational check failure is only reported
+	HealthInformational HealthCriticality = "informational"
+)
+
+const (
+	HealthStatusOK       = "OK"
+	HealthStatusDegraded = "DEGRADED"
+	HealthStatusNOK      = "NOK"
+	HealthStatusDraining = "DRAINING"
+)
+
+type HealthCheck struct {
+	Name        string
+	Criticality HealthCriticality
+	Check       func() error
+}
+
+type HealthCheckResult struct {
+	Status      string
+	Criticality HealthCriticality
+	Error       string `json:",omitempty"`
+}
+
+type healthState struct {
+	mu      sync.Mutex
+	status  string
+	running map[string]bool
+}
+
+func (s *Service) RegisterHealthChecks(checks []HealthCheck) {
+	for _, check := range checks {
+		if check.Check == nil {
+			log.Fatalf("healthErr: check %q has no Check function", check.Name)
+		}
+	}
+
+	s.HealthChecks = checks
+}
+
+// RegisterHealthChangeTask sets a task launched when the aggregated health status changes
+func (s *Service) RegisterHealthChangeTask(task func(from, to string)) {
+	s.HealthChangeTask = task
+}
+
+// Health runs registered checks concurrently and aggregates them: a failed critical check gives NOK,
+// a failed degraded-ok check gives DEGRADED, informational checks don't affect the status.
+// A check which doesn't finish within common.health.timeout seconds (5 by default) fails.
+// DRAINING overrides everything while the service is stopping
+func (s *Service) Health() (string, map[string]HealthCheckResult) {
+	timeout := s.healthTimeout()
+	results := make(map[string]HealthCheckResult, len(s.HealthChecks))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range s.HealthChecks {
+		wg.Add(1)
+
+		go func(check HealthCheck) {
+			defer wg.Done()
+
+			criticality := check.Criticality
+			if criticality == "" {
+				criticality = HealthCritical
+			}
+
+			result := HealthCheckResult{Status: HealthStatusOK, Criticality: criticality}
+
+			if err := s.runHealthCheck(check, timeout); err != nil {
+				result.Status = HealthStatusNOK
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+
+	wg.Wait()
+
+	status := HealthStatusOK
+
+	for _, result := range results {
+		if result.Status == HealthStatusOK {
+			continue
+		}
+
+		switch result.Criticality {
+		case HealthCritical:
+			status = HealthStatusNOK
+		case HealthDegradedOk:
+			if status == HealthStatusOK {
+				status = HealthStatusDegraded
+			}
+		}
+	}
+
+	if s.Draining() {
+		status = HealthStatusDraining
+	}
+
+	s.setHealthStatus(status)
+
+	return status, results
+}
+
+// runHealthCheck waits for the check up to timeout. A check that is still running from
+// a previous call is not started again, so hanging checks don't pile up goroutines
+func (s *Service) runHealthCheck(check HealthCheck, timeout time.Duration) error {
+	if !s.health.start(check.Name) {
+		return errors.New("previous check is still running")
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer s.health.finish(check.Name)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+
+		done <- check.Check()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("check timed out after %s", timeout)
+	}
+}
+
+func (s *Service) healthTimeout() time.Duration {
+	switch timeout := s.GetConfig("common.health.timeout", 5).(type) {
+	case int:
+		return time.Duration(timeout) * time.Second
+	case float64:
+		return time.Duration(timeout * float64(time.Second))
+	}
+
+	return 5 * time.Second
+}
+
+func (h *healthState) start(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.running == nil {
+		h.running = map[string]bool{}
+	}
+
+	if h.running[name] {
+		return false
+	}
+
+	h.running[name] = true
+
+	return true
+}
+
+func (h *healthState) finish(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.running, name)
+}
+
+func (s *Service) setHealthStatus(status string) {
+	s.health.mu.Lock()
+	previous := s.health.status
+	s.health.status = status
+	s.health.mu.Unlock()
+
+	if previous == "" || previous == status {
+		return
+	}
+
+	log.Printf("Health status changed: %s -> %s", previous, status)
+
+	if s.HealthChangeTask != nil {
+		go s.HealthChangeTask(previous, status)
+	}
+}
+
+func (s *Service) healthCheck(resp http.ResponseWriter, req *http.Request) {
+	status, results := s.Health()
+
+	data := map[string]interface{}{"Status": status}
+	if len(results) > 0 {
+		data["Checks"] = results
+	}
+
+	code := http.StatusOK
+	if status == HealthStatusNOK || status == HealthStatusDraining {
+		code = http.StatusServiceUnavailable
+	}
+
+	body, _ := json.Marshal(data)
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+	resp.Write(body)
+}
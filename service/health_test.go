@@ -0,0 +1,45 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthTimesOutHangingCheck(t *testing.T) {
+	s := newTestService(map[string]interface{}{
+		"common": map[string]interface{}{
+			"health": map[string]interface{}{"timeout": 0.05},
+		},
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+
+	s.RegisterHealthChecks([]HealthCheck{
+		{Name: "hanging", Criticality: HealthCritical, Check: func() error { <-release; return nil }},
+		{Name: "cache", Criticality: HealthDegradedOk, Check: func() error { return errors.New("down") }},
+		{Name: "storage", Criticality: HealthCritical, Check: func() error { return nil }},
+	})
+
+	started := time.Now()
+	status, results := s.Health()
+
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("health took %s, expected the timeout to apply", elapsed)
+	}
+
+	if status != HealthStatusNOK {
+		t.Errorf("expected %s, got %s", HealthStatusNOK, status)
+	}
+
+	if results["hanging"].Status != HealthStatusNOK || results["storage"].Status != HealthStatusOK {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	// The hanging check is still running and must not be started again
+	_, results = s.Health()
+	if results["hanging"].Error != "previous check is still running" {
+		t.Errorf("expected hanging check to be skipped, got %q", results["hanging"].Error)
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Http3Server is the experimental QUIC listener, it is provided by the
+// github.com/saiset-co/sai-service/http3 module, so the core doesn't depend on quic-go
+type Http3Server interface {
+	ListenAndServeTLS(certFile, keyFile string) error
+	SetQUICHeaders(header http.Header) error
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+type Http3Factory func(addr string, handler http.Handler) Http3Server
+
+// RegisterHttp3 enables the http3 option of TLS listeners
+func (s *Service) RegisterHttp3(factory Http3Factory) {
+	s.http3Factory = factory
+}
+
+// serveHttp3 starts the experimental QUIC listener on the UDP port of the TLS server
+// and advertises it with Alt-Svc from the TCP listener
+func (s *Service) serveHttp3(name string, server *http.Server, certFile, keyFile string) {
+	if s.http3Factory == nil {
+		log.Println(name + " HTTP/3 is enabled, but not registered, see the sai-service/http3 module")
+		return
+	}
+
+	quicServer := s.http3Factory(server.Addr, server.Handler)
+
+	handler := server.Handler
+	server.Handler = http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		_ = quicServer.SetQUICHeaders(resp.Header())
+		handler.ServeHTTP(resp, req)
+	})
+
+	s.serversMu.Lock()
+	s.quicServers = append(s.quicServers, quicServer)
+	s.serversMu.Unlock()
+
+	go func() {
+		err := quicServer.ListenAndServeTLS(certFile, keyFile)
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println(name+" HTTP/3 server error: ", err)
+		}
+	}()
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// handleSignals blocks until SIGINT/SIGTERM, reloading the config on SIGHUP
+func (s *Service) handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			if err := s.Reload(); err != nil {
+				log.Println("Config reload error: ", err)
+			}
+			continue
+		}
+
+		log.Printf("%s got %s, stopping", s.Name, sig)
+		s.Shutdown()
+		return
+	}
+}
+
+// Reload re-reads the registered config file and launches the reload task
+func (s *Service) Reload() error {
+	if s.configPath == "" {
+		return errors.New("config was not registered")
+	}
+
+	sdNotify("RELOADING=1")
+	defer sdNotify("READY=1")
+
+	yamlData, err := os.ReadFile(s.configPath)
+	if err != nil {
+		return err
+	}
+
+	configuration := map[string]interface{}{}
+	if err = yaml.Unmarshal(yamlData, &configuration); err != nil {
+		return err
+	}
+
+	if err = decryptConfiguration(configuration); err != nil {
+		return err
+	}
+
+	s.Context.SetConfiguration(configuration)
+	s.SetLogger()
+	s.Context.SetValue("logger", s.Logger)
+
+	if s.ReloadTask != nil {
+		s.ReloadTask()
+	}
+
+	log.Printf("%s config has been reloaded", s.Name)
+
+	return nil
+}
+
+// Shutdown drains for common.shutdown.pre_stop_delay seconds (health reports DRAINING and responses
+// close keep-alive connections) so orchestrators can remove the instance from load balancing,
+// then gracefully stops the servers waiting for in-flight requests within common.shutdown.timeout
+func (s *Service) Shutdown() {
+	sdNotify("STOPPING=1")
+	s.drain.draining.Store(true)
+
+	delay := s.GetConfig("common.shutdown.pre_stop_delay", 0).(int)
+	if delay > 0 {
+		log.Printf("Draining for %ds before shutdown", delay)
+		time.Sleep(time.Duration(delay) * time.Second)
+	}
+
+	if inFlight := s.InFlight(); inFlight > 0 {
+		log.Printf("Waiting for %d in-flight requests", inFlight)
+	}
+
+	timeout := s.GetConfig("common.shutdown.timeout", 30).(int)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	s.serversMu.Lock()
+	servers := s.servers
+	quicServers := s.quicServers
+	s.serversMu.Unlock()
+
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			log.Println("Server shutdown error: ", err)
+		}
+	}
+
+	for _, server := range quicServers {
+		if err := server.Shutdown(ctx); err != nil {
+			log.Println("HTTP/3 server shutdown error: ", err)
+			_ = server.Close()
+		}
+	}
+
+	log.Printf("%s has been stopped", s.Name)
+}
+
+// sdNotify sends the state to systemd when the service is started with Type=notify
+func sdNotify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	if socket[0] == '@' {
+		addr.Name = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		log.Println("sd_notify error: ", err)
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte(state)); err != nil {
+		log.Println("sd_notify error: ", err)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/netutil"
+)
+
+type ipLimitListener struct {
+	net.Listener
+	limit int
+
+	mu    sync.Mutex
+	conns map[string]int
+}
+
+type ipLimitConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *ipLimitConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
+// limitListener applies the <prefix>.max_connections and <prefix>.max_connections_per_ip options.
+// Over the global limit new connections wait in the accept queue, over the per-IP limit they are closed
+func (s *Service) limitListener(prefix string, ln net.Listener) net.Listener {
+	if perIP := s.GetConfig(prefix+".max_connections_per_ip", 0).(int); perIP > 0 {
+		ln = &ipLimitListener{Listener: ln, limit: perIP, conns: map[string]int{}}
+	}
+
+	if max := s.GetConfig(prefix+".max_connections", 0).(int); max > 0 {
+		ln = netutil.LimitListener(ln, max)
+	}
+
+	return ln
+}
+
+func (l *ipLimitListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip, _, splitErr := net.SplitHostPort(conn.RemoteAddr().String())
+		if splitErr != nil {
+			return conn, nil
+		}
+
+		l.mu.Lock()
+		if l.conns[ip] >= l.limit {
+			l.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		l.conns[ip]++
+		l.mu.Unlock()
+
+		return &ipLimitConn{Conn: conn, release: func() { l.release(ip) }}, nil
+	}
+}
+
+func (l *ipLimitListener) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conns[ip]--; l.conns[ip] <= 0 {
+		delete(l.conns, ip)
+	}
+}
+
+// limitInFlight answers 503 with Retry-After (<prefix>.retry_after seconds, 1 by default)
+// while <prefix>.max_in_flight requests are already being processed
+func (s *Service) limitInFlight(prefix string, next http.Handler) http.Handler {
+	max := s.GetConfig(prefix+".max_in_flight", 0).(int)
+	if max <= 0 {
+		return next
+	}
+
+	retryAfter := strconv.Itoa(s.GetConfig(prefix+".retry_after", 1).(int))
+	slots := make(chan struct{}, max)
+
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			next.ServeHTTP(resp, req)
+		default:
+			resp.Header().Set("Retry-After", retryAfter)
+			s.writeError(resp, http.StatusServiceUnavailable, "Server is busy")
+		}
+	})
+}
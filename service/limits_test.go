@@ -0,0 +1,64 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInFlightRejectionsAreAccessLogged(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "access.log")
+
+	s := newTestService(map[string]interface{}{
+		"common": map[string]interface{}{
+			"http":       map[string]interface{}{"port": 0, "max_in_flight": 1},
+			"access_log": map[string]interface{}{"enabled": true, "format": "common", "output": logPath},
+		},
+	})
+
+	server, ln, err := s.listenHttp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	s.Handlers = Handler{
+		"wait": {Name: "wait", Function: func(data, metadata interface{}) (interface{}, int, error) {
+			close(started)
+			<-release
+			return "ok", http.StatusOK, nil
+		}},
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"method":"wait"}`))
+		server.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-started
+
+	resp := httptest.NewRecorder()
+	server.Handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"method":"wait"}`)))
+
+	close(release)
+	<-done
+
+	if resp.Code != http.StatusServiceUnavailable || resp.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After, got %d", resp.Code)
+	}
+
+	accessLog, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(accessLog), `"POST / HTTP/1.1" 503`) {
+		t.Errorf("503 is missing from the access log:\n%s", accessLog)
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var readMethods = []string{http.MethodGet, http.MethodHead}
+
+// rpcMethods returns the methods accepted by the handler endpoint from common.http.methods
+func (s *Service) rpcMethods() []string {
+	methods := s.getConfigStrings("common.http.methods")
+	for i, method := range methods {
+		methods[i] = strings.ToUpper(method)
+	}
+
+	return methods
+}
+
+// allowMethods answers OPTIONS with the Allow header and rejects other methods with 405
+func (s *Service) allowMethods(methods []string, next http.Handler) http.Handler {
+	allow := strings.Join(append(slices.Clone(methods), http.MethodOptions), ", ")
+
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodOptions {
+			resp.Header().Set("Allow", allow)
+			resp.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if !slices.Contains(methods, req.Method) {
+			resp.Header().Set("Allow", allow)
+			s.writeError(resp, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		next.ServeHTTP(resp, req)
+	})
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+)
+
+// registerProfiling mounts /debug/pprof/* behind the common.internal.profiling protection,
+// endpoints are never registered without it
+func (s *Service) registerProfiling(r *http.ServeMux) bool {
+	if !s.GetConfig("common.profiling.enabled", false).(bool) {
+		return false
+	}
+
+	if !s.isProtected("profiling") {
+		log.Println("Profiling error: common.internal.profiling protection is not configured, pprof endpoints are disabled")
+		return false
+	}
+
+	r.Handle("/debug/pprof/", s.protect("profiling", http.HandlerFunc(pprof.Index)))
+	r.Handle("/debug/pprof/cmdline", s.protect("profiling", http.HandlerFunc(pprof.Cmdline)))
+	r.Handle("/debug/pprof/profile", s.protect("profiling", http.HandlerFunc(pprof.Profile)))
+	r.Handle("/debug/pprof/symbol", s.protect("profiling", http.HandlerFunc(pprof.Symbol)))
+	r.Handle("/debug/pprof/trace", s.protect("profiling", http.HandlerFunc(pprof.Trace)))
+
+	return true
+}
+
+func (s *Service) listenProfiling() (*http.Server, net.Listener, error) {
+	port := s.GetConfig("common.profiling.port", 6060).(int)
+
+	r := http.NewServeMux()
+
+	if !s.registerProfiling(r) {
+		return nil, nil, errors.New("profiling endpoints are disabled")
+	}
+
+	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: r}
+
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.addServer(server)
+
+	return server, ln, nil
+}
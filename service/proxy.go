@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type ProxyConfig struct {
+	Upstream string
+	// StripPrefix removes the registered prefix from the path before forwarding
+	StripPrefix bool
+	// Timeout limits waiting for upstream response headers, the body is streamed without limit
+	Timeout time.Duration
+}
+
+// RegisterProxy forwards HTTP requests under prefix to the upstream with X-Forwarded-* headers.
+// Requests need the common.token like handler calls, plus the common.internal.proxy protection
+func (s *Service) RegisterProxy(prefix string, config ProxyConfig) {
+	upstream, err := url.Parse(config.Upstream)
+
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		log.Fatalf("proxyErr: wrong upstream %q for %s", config.Upstream, prefix)
+	}
+
+	if s.Proxies == nil {
+		s.Proxies = map[string]ProxyConfig{}
+	}
+
+	s.Proxies["/"+strings.Trim(prefix, "/")+"/"] = config
+}
+
+func (s *Service) registerProxies(r *http.ServeMux) {
+	for prefix, config := range s.Proxies {
+		r.Handle(prefix, s.requireToken(s.protect("proxy", s.newReverseProxy(prefix, config))))
+	}
+}
+
+func (s *Service) newReverseProxy(prefix string, config ProxyConfig) http.Handler {
+	upstream, _ := url.Parse(config.Upstream)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = config.Timeout
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			if config.StripPrefix {
+				r.Out.URL.Path = "/" + strings.TrimPrefix(r.In.URL.Path, prefix)
+				r.Out.URL.RawPath = ""
+			}
+
+			r.SetURL(upstream)
+			r.SetXForwarded()
+		},
+		Transport:     transport,
+		FlushInterval: -1,
+		ErrorHandler: func(resp http.ResponseWriter, req *http.Request, err error) {
+			// The error names upstream addresses, so clients get only a generic message
+			log.Printf("Proxy %s error: %v", prefix, err)
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				s.writeError(resp, http.StatusGatewayTimeout, "Gateway timeout")
+				return
+			}
+
+			s.writeError(resp, http.StatusBadGateway, "Bad gateway")
+		},
+	}
+}
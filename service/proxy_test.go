@@ -0,0 +1,77 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxyErrorHidesUpstreamAddress(t *testing.T) {
+	s := newTestService(map[string]interface{}{})
+
+	// A closed listener gives an address which refuses connections
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := "http://" + ln.Addr().String()
+	ln.Close()
+
+	proxy := s.newReverseProxy("/legacy", ProxyConfig{Upstream: upstream})
+
+	resp := httptest.NewRecorder()
+	proxy.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/legacy/users", nil))
+
+	if resp.Code != http.StatusBadGateway {
+		t.Errorf("expected %d, got %d", http.StatusBadGateway, resp.Code)
+	}
+
+	if body := resp.Body.String(); strings.Contains(body, ln.Addr().String()) || !strings.Contains(body, "Bad gateway") {
+		t.Errorf("unexpected error body %s", body)
+	}
+}
+
+func TestProxyRequiresToken(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Write([]byte("upstream"))
+	}))
+	defer upstream.Close()
+
+	s := newTestService(map[string]interface{}{
+		"common": map[string]interface{}{"token": "T"},
+	})
+	s.RegisterProxy("/legacy", ProxyConfig{Upstream: upstream.URL})
+
+	r := http.NewServeMux()
+	s.registerProxies(r)
+
+	tests := []struct {
+		name     string
+		token    string
+		expected int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "X", http.StatusUnauthorized},
+		{"token", "T", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/legacy/x", nil)
+		if test.token != "" {
+			req.Header.Set("Token", test.token)
+		}
+
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+
+		if resp.Code != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, resp.Code)
+		}
+
+		if test.expected != http.StatusOK && strings.Contains(resp.Body.String(), "upstream") {
+			t.Errorf("%s: upstream body was returned", test.name)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type StartupReport struct {
+	Name         string
+	Version      string
+	StartedAt    time.Time
+	DurationMs   float64
+	Handlers     int
+	Middlewares  int
+	Tasks        int
+	HealthChecks int
+	Components   []StartupComponent
+}
+
+type StartupComponent struct {
+	Name       string
+	Enabled    bool
+	Address    string  `json:",omitempty"`
+	DurationMs float64 `json:",omitempty"`
+	Error      string  `json:",omitempty"`
+}
+
+func (r *StartupReport) addComponent(name string, enabled bool, started time.Time, ln net.Listener, err error) {
+	component := StartupComponent{Name: name, Enabled: enabled}
+
+	if enabled {
+		component.DurationMs = durationMs(time.Since(started))
+	}
+
+	if ln != nil {
+		component.Address = ln.Addr().String()
+	}
+
+	if err != nil {
+		component.Error = err.Error()
+	}
+
+	r.Components = append(r.Components, component)
+}
+
+// logStartupReport writes the report as a single structured entry and keeps it for /admin/startup
+func (s *Service) logStartupReport(report *StartupReport) {
+	s.startupMu.Lock()
+	s.startupReport = report
+	s.startupMu.Unlock()
+
+	if s.Logger != nil {
+		s.Logger.Info("Startup report", zap.Any("report", report))
+		return
+	}
+
+	body, _ := json.Marshal(report)
+	log.Printf("Startup report: %s", body)
+}
+
+func (s *Service) startupCheck(resp http.ResponseWriter, req *http.Request) {
+	s.startupMu.Lock()
+	report := s.startupReport
+	s.startupMu.Unlock()
+
+	resp.Header().Set("Content-Type", "application/json")
+
+	if report == nil {
+		s.writeError(resp, http.StatusServiceUnavailable, "Service is starting")
+		return
+	}
+
+	body, _ := json.Marshal(report)
+	resp.WriteHeader(http.StatusOK)
+	resp.Write(body)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+)
+
+type RouteInfo struct {
+	Method      string
+	Description string
+	Admin       bool
+	Middlewares int
+	Request     string `json:",omitempty"`
+}
+
+// Routes lists registered handlers sorted by method
+func (s *Service) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(s.Handlers))
+
+	for method, handler := range s.Handlers {
+		route := RouteInfo{
+			Method:      method,
+			Description: handler.Description,
+			Admin:       handler.Admin,
+			Middlewares: len(s.Middlewares) + len(handler.Middlewares),
+		}
+
+		if handler.Request != nil {
+			route.Request = reflect.TypeOf(handler.Request).String()
+		}
+
+		routes = append(routes, route)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Method < routes[j].Method
+	})
+
+	return routes
+}
+
+// errCommandConflict is returned for handlers which are served, but can't be a CLI command
+var errCommandConflict = errors.New("conflicts with a built-in command")
+
+// checkHandler fails on handlers which can't be served or would shadow built-in CLI commands
+func checkHandler(app *cli.App, method string, handler HandlerElement) error {
+	if method == "" {
+		return fmt.Errorf("handler %q has an empty method", handler.Name)
+	}
+
+	if handler.Function == nil {
+		return fmt.Errorf("handler %s has no Function", method)
+	}
+
+	// help is added by cli on Run, so it is checked explicitly
+	if app.Command(method) != nil || method == "help" || method == "h" {
+		return fmt.Errorf("handler %s %w %s", method, errCommandConflict, method)
+	}
+
+	return nil
+}
+
+func (s *Service) routesCheck(resp http.ResponseWriter, req *http.Request) {
+	body, _ := json.Marshal(s.Routes())
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(http.StatusOK)
+	resp.Write(body)
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestCheckHandler(t *testing.T) {
+	app := &cli.App{Commands: []*cli.Command{{Name: "start"}, {Name: "encrypt"}}}
+	function := func(data, metadata interface{}) (interface{}, int, error) { return nil, http.StatusOK, nil }
+
+	for _, method := range []string{"start", "encrypt", "help", "h"} {
+		if err := checkHandler(app, method, HandlerElement{Function: function}); !errors.Is(err, errCommandConflict) {
+			t.Errorf("%s: expected command conflict, got %v", method, err)
+		}
+	}
+
+	if err := checkHandler(app, "", HandlerElement{Function: function}); err == nil || errors.Is(err, errCommandConflict) {
+		t.Errorf("empty method: expected fatal error, got %v", err)
+	}
+
+	if err := checkHandler(app, "get", HandlerElement{}); err == nil || errors.Is(err, errCommandConflict) {
+		t.Errorf("nil function: expected fatal error, got %v", err)
+	}
+
+	if err := checkHandler(app, "get", HandlerElement{Function: function}); err != nil {
+		t.Errorf("get: unexpected error %v", err)
+	}
+}
@@ -1,48 +1,154 @@
 package service
 
 import (
+	"crypto/tls"
+	"errors"
 	"log"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rs/cors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"golang.org/x/net/websocket"
 )
 
 func (s *Service) StartHttp() {
+	server, ln, err := s.listenHttp()
+
+	if err != nil {
+		log.Println("Http server error: ", err)
+		return
+	}
+
+	log.Println("Http server has been started:", ln.Addr().String())
+	s.serve("Http", server, ln)
+}
+
+func (s *Service) StartWS() {
+	server, ln, err := s.listenWS()
+
+	if err != nil {
+		log.Println("WS server error: ", err)
+		return
+	}
+
+	log.Println("WS server has been started:", ln.Addr().String())
+	s.serve("WS", server, ln)
+}
+
+func (s *Service) listenHttp() (*http.Server, net.Listener, error) {
 	port := s.GetConfig("common.http.port", 8080).(int)
-	log.Println("Http server has been started:", port)
-	handler := http.HandlerFunc(s.handleHttpConnections)
-	healthHandler := http.HandlerFunc(s.healthCheck)
-	versionHandler := http.HandlerFunc(s.versionCheck)
+	var handler http.Handler = http.HandlerFunc(s.handleHttpConnections)
 
-	// Wrap the handler with the cors handler
+	// Wrap the handler with the cors handler, with common.http.methods the endpoint
+	// and preflight allow only the listed methods, otherwise any method is accepted
 	corsHandler := cors.AllowAll().Handler(handler)
 
-	http.Handle("/", corsHandler)
-	http.Handle("/check", healthHandler)
-	http.Handle("/version", versionHandler)
+	if methods := s.rpcMethods(); len(methods) > 0 {
+		corsHandler = cors.New(cors.Options{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: methods,
+			AllowedHeaders: []string{"*"},
+		}).Handler(s.allowMethods(methods, handler))
+	}
 
-	err := http.ListenAndServe(":"+strconv.Itoa(port), nil)
+	r := http.NewServeMux()
+
+	r.Handle("/", corsHandler)
+	s.registerProxies(r)
+
+	if !s.adminEnabled() {
+		s.registerOperational(r)
+	}
 
+	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: s.accessLog(s.trackRequests(s.limitInFlight("common.http", r)))}
+
+	ln, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		log.Println("Http server error: ", err)
+		return nil, nil, err
 	}
+
+	s.addServer(server)
+
+	return server, ln, nil
 }
 
-func (s *Service) StartWS() {
+func (s *Service) listenWS() (*http.Server, net.Listener, error) {
 	port := s.GetConfig("common.ws.port", 8081).(int)
-	log.Println("WS server has been started:", port)
 
 	r := http.NewServeMux()
 
 	r.Handle("/ws", websocket.Handler(s.handleWSConnections))
 
-	err := http.ListenAndServe(":"+strconv.Itoa(port), r)
+	// WebSocket handshake requires HTTP/1.1 connection hijacking, so HTTP/2 is never negotiated here
+	server := &http.Server{
+		Addr:         ":" + strconv.Itoa(port),
+		Handler:      s.limitInFlight("common.ws", r),
+		TLSNextProto: map[string]func(*http.Server, *tls.Conn, http.Handler){},
+	}
 
+	ln, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		log.Println("WS server error: ", err)
+		return nil, nil, err
+	}
+
+	s.addServer(server)
+
+	return server, ln, nil
+}
+
+func (s *Service) startServer(report *StartupReport, name string, enabled bool, listen func() (*http.Server, net.Listener, error)) {
+	started := time.Now()
+
+	if !enabled {
+		report.addComponent(strings.ToLower(name), false, started, nil, nil)
+		return
+	}
+
+	server, ln, err := listen()
+	report.addComponent(strings.ToLower(name), true, started, ln, err)
+
+	if err != nil {
+		log.Println(name+" server error: ", err)
+		return
+	}
+
+	go s.serve(name, server, ln)
+}
+
+// serve starts the server with the common.<name> listener options: tls.cert_file/tls.key_file
+// for HTTPS (HTTP/2 is negotiated unless http2 is false, http3 adds an experimental QUIC listener)
+// and h2c for cleartext HTTP/2, plus the connection limits
+func (s *Service) serve(name string, server *http.Server, ln net.Listener) {
+	prefix := "common." + strings.ToLower(name)
+	certFile := s.GetConfig(prefix+".tls.cert_file", "").(string)
+	keyFile := s.GetConfig(prefix+".tls.key_file", "").(string)
+
+	ln = s.limitListener(prefix, ln)
+
+	if !s.GetConfig(prefix+".http2", true).(bool) {
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	} else if s.GetConfig(prefix+".h2c", false).(bool) {
+		server.Handler = h2c.NewHandler(server.Handler, &http2.Server{})
+	}
+
+	var err error
+	if certFile != "" && keyFile != "" {
+		if s.GetConfig(prefix+".http3", false).(bool) {
+			s.serveHttp3(name, server, certFile, keyFile)
+		}
+
+		err = server.ServeTLS(ln, certFile, keyFile)
+	} else {
+		err = server.Serve(ln)
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Println(name+" server error: ", err)
 	}
 }
 
@@ -64,3 +170,10 @@ func (s *Service) StartSocket() {
 
 	s.handleSocketConnections(conn)
 }
+
+func (s *Service) addServer(server *http.Server) {
+	s.serversMu.Lock()
+	defer s.serversMu.Unlock()
+
+	s.servers = append(s.servers, server)
+}
@@ -1,10 +1,16 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"go.uber.org/zap/zapcore"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
@@ -17,8 +23,28 @@ type Service struct {
 	Handlers    Handler
 	Tasks       []func()
 	InitTask    func()
+	ReloadTask  func()
 	Logger      *zap.Logger
 	Middlewares []Middleware
+
+	HealthChecks     []HealthCheck
+	HealthChangeTask func(from, to string)
+	Proxies          map[string]ProxyConfig
+	ErrorHandler     ErrorHandler
+	NotFoundHandler  HandlerFunc
+
+	configPath    string
+	health        healthState
+	drain         drainState
+	accessLogOnce sync.Once
+	accessLogger  *accessLogger
+	initDuration  time.Duration
+	startupMu     sync.Mutex
+	startupReport *StartupReport
+	serversMu     sync.Mutex
+	servers       []*http.Server
+	quicServers   []Http3Server
+	http3Factory  Http3Factory
 }
 
 var svc = new(Service)
@@ -42,6 +68,15 @@ func (s *Service) RegisterConfig(path string) {
 	if err != nil {
 		log.Fatalf("yamlErr: %v", err)
 	}
+
+	err = decryptConfiguration(s.Context.Configuration)
+
+	if err != nil {
+		log.Fatalf("configErr: %v", err)
+	}
+
+	s.configPath = path
+
 	svc.SetLogger()
 	svc.Context.SetValue("logger", svc.Logger)
 }
@@ -62,6 +97,11 @@ func (s *Service) RegisterInitTask(initTask func()) {
 	s.InitTask = initTask
 }
 
+// RegisterReloadTask sets a task launched after the config was reloaded by SIGHUP
+func (s *Service) RegisterReloadTask(reloadTask func()) {
+	s.ReloadTask = reloadTask
+}
+
 func (s *Service) GetConfig(path string, def interface{}) interface{} {
 	return s.Context.GetConfig(path, def)
 }
@@ -79,7 +119,9 @@ func (s *Service) GetBuild(def string) string {
 
 func (s *Service) Start() {
 	if s.InitTask != nil {
+		started := time.Now()
 		s.InitTask()
+		s.initDuration = time.Since(started)
 	}
 
 	app := &cli.App{
@@ -92,10 +134,51 @@ func (s *Service) Start() {
 					return nil
 				},
 			},
+			{
+				Name:      "encrypt",
+				Usage:     "Encrypt config value with the key from " + ConfigKeyEnv + ", the value is read from stdin when not given",
+				ArgsUsage: "[value]",
+				Action: func(c *cli.Context) error {
+					key, err := ConfigKey()
+					if err != nil {
+						return err
+					}
+
+					plain := c.Args().Get(0)
+					if !c.Args().Present() {
+						// Reading from stdin keeps the secret out of shell history and ps output
+						input, err := io.ReadAll(os.Stdin)
+						if err != nil {
+							return fmt.Errorf("error while reading value : %w", err)
+						}
+
+						plain = strings.TrimRight(string(input), "\r\n")
+					}
+
+					if plain == "" {
+						return errors.New("empty value provided")
+					}
+
+					value, err := EncryptValue(key, plain)
+					if err != nil {
+						return fmt.Errorf("error while encrypting value : %w", err)
+					}
+
+					fmt.Println(value)
+					return nil
+				},
+			},
 		},
 	}
 
 	for method, handler := range s.Handlers {
+		if err := checkHandler(app, method, handler); errors.Is(err, errCommandConflict) {
+			log.Printf("handlerWarn: %v, it is served without a CLI command", err)
+			continue
+		} else if err != nil {
+			log.Fatalf("handlerErr: %v", err)
+		}
+
 		command := new(cli.Command)
 		command.Name = method
 		command.Usage = handler.Description
@@ -129,21 +212,43 @@ func (s *Service) StartServices() {
 	useHttp := s.GetConfig("common.http.enabled", true).(bool)
 	useWS := s.GetConfig("common.ws.enabled", true).(bool)
 
-	if useHttp {
-		go s.StartHttp()
+	started := time.Now()
+	report := &StartupReport{
+		Name:         s.Name,
+		Version:      s.GetConfig("common.version", "0.1").(string),
+		StartedAt:    started,
+		Handlers:     len(s.Handlers),
+		Middlewares:  len(s.Middlewares),
+		Tasks:        len(s.Tasks),
+		HealthChecks: len(s.HealthChecks),
 	}
-
-	if useWS {
-		go s.StartWS()
+	report.Components = append(report.Components, StartupComponent{
+		Name:       "init",
+		Enabled:    s.InitTask != nil,
+		DurationMs: durationMs(s.initDuration),
+	})
+
+	s.startServer(report, "Http", useHttp, s.listenHttp)
+	s.startServer(report, "WS", useWS, s.listenWS)
+	s.startServer(report, "Admin", s.adminEnabled(), s.listenAdmin)
+
+	if s.GetConfig("common.profiling.port", 0).(int) != 0 {
+		s.startServer(report, "Profiling", s.GetConfig("common.profiling.enabled", false).(bool), s.listenProfiling)
 	}
 
+	componentStarted := time.Now()
 	s.StartTasks()
+	report.addComponent("tasks", len(s.Tasks) > 0, componentStarted, nil, nil)
+
+	report.DurationMs = durationMs(time.Since(started))
+	s.logStartupReport(report)
 
 	log.Printf("%s has been started!", s.Name)
+	sdNotify("READY=1")
 
 	//s.StartSocket() -- Commented because overload CPU usage
 
-	select {}
+	s.handleSignals()
 }
 
 func (s *Service) StartTasks() {
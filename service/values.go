@@ -0,0 +1,88 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Well-known metadata keys shared by transports, middlewares and handlers
+const (
+	// MetadataIP is taken from forwarding headers as sent by the client, use MetadataClientIP for access control
+	MetadataIP = "ip"
+	// MetadataClientIP is the connection address, or the forwarded one behind common.trusted_proxies
+	MetadataClientIP  = "client_ip"
+	MetadataToken     = "token"
+	MetadataFields    = "fields"
+	MetadataRequestID = "request_id"
+	MetadataPrincipal = "principal"
+	MetadataTenant    = "tenant"
+	MetadataCountry   = "country"
+	MetadataASN       = "asn"
+	// MetadataResponseHeaders holds the HTTP response headers, use SetResponseHeader
+	MetadataResponseHeaders = "response_headers"
+)
+
+// GetMetadata returns the metadata value stored under key if it has type T
+func GetMetadata[T any](metadata interface{}, key string) (T, bool) {
+	var zero T
+
+	metadataMap, ok := metadata.(map[string]interface{})
+	if !ok {
+		return zero, false
+	}
+
+	value, ok := metadataMap[key].(T)
+	if !ok {
+		return zero, false
+	}
+
+	return value, true
+}
+
+// SetMetadata stores the value for next middlewares and the handler, metadata must be a map
+func SetMetadata(metadata interface{}, key string, value interface{}) bool {
+	metadataMap, ok := metadata.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	metadataMap[key] = value
+
+	return true
+}
+
+// SetResponseHeader adds the header to the HTTP response, it is ignored by other transports
+func SetResponseHeader(metadata interface{}, key string, value string) bool {
+	headers, ok := GetMetadata[http.Header](metadata, MetadataResponseHeaders)
+	if !ok {
+		return false
+	}
+
+	headers.Set(key, value)
+
+	return true
+}
+
+// reservedMetadata keys are set by the service and middlewares only, transports drop them from client metadata
+var reservedMetadata = []string{
+	MetadataClientIP,
+	MetadataPrincipal,
+	MetadataTenant,
+	MetadataCountry,
+	MetadataASN,
+	MetadataResponseHeaders,
+}
+
+func stripReservedMetadata(metadata map[string]interface{}) {
+	for _, key := range reservedMetadata {
+		delete(metadata, key)
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}